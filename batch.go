@@ -0,0 +1,56 @@
+package logbuf
+
+import "time"
+
+// DropPolicy controls what a batching Buffer does when its internal write
+// queue is full.
+type DropPolicy int
+
+const (
+	// DropOldest discards the oldest queued entry to make room for the new
+	// one.
+	DropOldest DropPolicy = iota
+
+	// DropNewest discards the entry currently being written, leaving the
+	// queue untouched.
+	DropNewest
+
+	// Block makes Write/WriteEntry wait until the queue has room.
+	Block
+)
+
+// BatchConfig enables asynchronous batched writes for drivers that support
+// it (currently only the SQLite driver). Instead of a synchronous INSERT per
+// Write, entries are queued and flushed together in a single transaction
+// every MaxBatch entries or FlushInterval, whichever comes first.
+type BatchConfig struct {
+	// MaxBatch is the number of queued entries that triggers an immediate
+	// flush. Zero means entries only flush on FlushInterval or Close/Flush.
+	MaxBatch int
+
+	// FlushInterval is the maximum time entries sit in the queue before
+	// being flushed. Defaults to one second if zero.
+	FlushInterval time.Duration
+
+	// QueueSize is the capacity of the internal write queue. Defaults to 1
+	// if zero.
+	QueueSize int
+
+	// DropPolicy controls what happens when the queue is full.
+	DropPolicy DropPolicy
+}
+
+// Stats reports counters for a Buffer's internal write queue. Buffers that
+// write synchronously (the default, no BatchConfig) always return a zero
+// Stats.
+type Stats struct {
+	// Enqueued is the number of entries successfully queued.
+	Enqueued int64
+
+	// Dropped is the number of entries discarded because the queue was full
+	// and DropPolicy was DropOldest or DropNewest.
+	Dropped int64
+
+	// Flushed is the number of entries committed to storage so far.
+	Flushed int64
+}
@@ -0,0 +1,130 @@
+package logbuf
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Driver identifies which storage backend a Buffer is created with.
+type Driver string
+
+const (
+	// DriverSQLite stores entries in a SQLite database. This is the default
+	// when Driver is left empty, matching the original behaviour of this
+	// package.
+	DriverSQLite Driver = "sqlite"
+
+	// DriverLevelDB stores entries in a LevelDB database. It has no cgo
+	// dependency, which makes it a good fit for cross-compiled or WASM
+	// builds where mattn/go-sqlite3 is impractical.
+	DriverLevelDB Driver = "leveldb"
+
+	// DriverMemory keeps entries in an in-process slice. Nothing is
+	// persisted to disk; the buffer is emptied when the process exits.
+	DriverMemory Driver = "memory"
+)
+
+// Config describes how to construct a Buffer via NewWithConfig.
+type Config struct {
+	// Driver selects the storage backend. Defaults to DriverSQLite.
+	Driver Driver
+
+	// DBPath is the on-disk location of the database. Ignored by
+	// DriverMemory.
+	DBPath string
+
+	// MaxLines, when non-zero, caps the buffer to the newest MaxLines
+	// entries.
+	MaxLines int
+
+	// MaxAge, when non-zero, discards entries older than MaxAge.
+	MaxAge time.Duration
+
+	// MaxBytes, when non-zero, discards the oldest entries until the total
+	// size of the remaining ones is at most MaxBytes.
+	MaxBytes int64
+
+	// Batch, when non-nil, makes the SQLite driver write asynchronously: see
+	// BatchConfig. Ignored by drivers other than DriverSQLite.
+	Batch *BatchConfig
+}
+
+// Buffer describes temporary storage for unfiltered log entries. sqliteBuf,
+// levelBuf and memoryBuf are the backends shipped with this package;
+// NewWithConfig picks one of them based on a Driver, and New always uses
+// sqliteBuf.
+type Buffer interface {
+	io.Writer // Write(p []byte) (int, error)
+
+	// WriteString is a convenience wrapper around Write for UTF-8 strings.
+	WriteString(entry string) error
+
+	// Dump returns all currently buffered log entries ordered by their time of
+	// insertion – oldest first.
+	Dump() ([]string, error)
+
+	// WriteEntry appends a structured Entry, preserving its Level and Attrs
+	// instead of flattening them into a single string.
+	WriteEntry(ctx context.Context, e Entry) error
+
+	// Query returns entries matching filter, oldest first.
+	Query(filter Filter) ([]Entry, error)
+
+	// DumpJSON streams every currently buffered entry to w as
+	// newline-delimited JSON, oldest first.
+	DumpJSON(w io.Writer) error
+
+	// Flush blocks until every write enqueued so far has been committed to
+	// storage. Buffers that write synchronously (the default) return
+	// immediately.
+	Flush() error
+
+	// Stats returns counters for the buffer's internal write queue. Buffers
+	// that write synchronously always return a zero Stats.
+	Stats() Stats
+
+	// Snapshot writes a consistent, gzip-compressed NDJSON archive of every
+	// currently buffered entry to w, suitable for attaching to a bug report.
+	Snapshot(w io.Writer) error
+
+	// Compact reclaims space freed by prior deletions. It is safe to call at
+	// any time, including when there is nothing to reclaim.
+	Compact() error
+
+	// Close closes the underlying storage.
+	Close()
+
+	// Clear deletes all persisted entries. The buffer stays usable and
+	// recreates any on-disk state on the next operation.
+	Clear() error
+}
+
+// NewWithConfig returns a Buffer backed by cfg.Driver. At least one of
+// cfg.MaxLines, cfg.MaxAge or cfg.MaxBytes must be non-zero, otherwise an
+// error is returned. An empty cfg.Driver defaults to DriverSQLite.
+func NewWithConfig(cfg Config) (Buffer, error) {
+	switch cfg.Driver {
+	case DriverSQLite, "":
+		return newSQLiteBuffer(cfg.MaxLines, cfg.MaxAge, cfg.MaxBytes, cfg.DBPath, cfg.Batch)
+	case DriverLevelDB:
+		return newLevelDBBuffer(cfg.MaxLines, cfg.MaxAge, cfg.MaxBytes, cfg.DBPath)
+	case DriverMemory:
+		return newMemoryBuffer(cfg.MaxLines, cfg.MaxAge, cfg.MaxBytes)
+	default:
+		return nil, fmt.Errorf("logbuf: unknown driver %q", cfg.Driver)
+	}
+}
+
+// snapshotViaDumpJSON implements Buffer.Snapshot in terms of DumpJSON,
+// shared by every backend shipped with this package.
+func snapshotViaDumpJSON(buf Buffer, w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	if err := buf.DumpJSON(gz); err != nil {
+		_ = gz.Close()
+		return err
+	}
+	return gz.Close()
+}
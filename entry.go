@@ -0,0 +1,103 @@
+package logbuf
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Entry is a single structured log record, as written via WriteEntry and
+// returned by Query/DumpJSON.
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+// Filter narrows the result set returned by Query. The zero value matches
+// every entry.
+type Filter struct {
+	// MinLevel excludes entries below this level. A nil MinLevel applies no
+	// lower bound, matching every level including slog.LevelDebug; it is not
+	// equivalent to a pointer to the zero slog.Level (slog.LevelInfo).
+	MinLevel *slog.Level
+
+	// Since and Until bound Entry.Time, inclusive. A zero value leaves the
+	// respective bound open.
+	Since time.Time
+	Until time.Time
+
+	// AttrMatch, when non-nil, only matches entries whose Attrs contain
+	// every key/value pair given here.
+	AttrMatch map[string]any
+
+	// Limit caps the number of returned entries to the newest Limit
+	// matches. Zero means unlimited.
+	Limit int
+}
+
+// MinLevel returns a pointer to l, for use as Filter.MinLevel. Go does not
+// allow taking the address of a typed constant like slog.LevelInfo directly.
+func MinLevel(l slog.Level) *slog.Level {
+	return &l
+}
+
+// matchAttrs reports whether have contains every key/value pair in want.
+func matchAttrs(have, want map[string]any) bool {
+	for k, v := range want {
+		hv, ok := have[k]
+		if !ok || !attrValuesEqual(hv, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// attrValuesEqual compares two Attr values, treating any pair of numeric
+// kinds as equal if their float64 values match. The SQLite and LevelDB
+// backends persist Attrs via json.Marshal and read them back via
+// json.Unmarshal into map[string]any, which turns every int/int64/float32
+// attribute into a float64; without this, AttrMatch{"status": 200} would
+// never match a value written as slog.Int("status", 200) on those backends,
+// even though the same filter matches fine against the in-memory backend.
+func attrValuesEqual(a, b any) bool {
+	if af, ok := toFloat64(a); ok {
+		if bf, ok := toFloat64(b); ok {
+			return af == bf
+		}
+	}
+	return a == b
+}
+
+// toFloat64 reports the float64 value of v if it holds one of Go's built-in
+// numeric kinds, and whether v held a numeric kind at all.
+func toFloat64(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
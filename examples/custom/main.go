@@ -10,7 +10,7 @@ import (
 	"github.com/unkn0wncode/logbuf"
 )
 
-var lb logbuf.LogBuf
+var lb logbuf.Buffer
 
 func Debug(format string, a ...any) {
 	msg := fmt.Sprintf("DEBUG: "+format, a...)
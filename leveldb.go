@@ -0,0 +1,376 @@
+// Package logbuf / leveldb.go contains a LevelDB-based implementation of the
+// Buffer interface. It is pure Go in every build, unlike the SQLite driver
+// when built with the logbuf_cgo tag, which makes it a good fit for
+// cross-compiled or WASM builds.
+package logbuf
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// retentionInterval is how often the background goroutine checks maxAge/maxLines.
+const retentionInterval = time.Second
+
+// levelRecord is the JSON envelope stored under each key, carrying an
+// Entry's fields so Query can recover them without a separate index.
+type levelRecord struct {
+	Time    time.Time      `json:"time"`
+	Message string         `json:"message"`
+	Level   slog.Level     `json:"level"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+}
+
+// levelBuf implements Buffer on top of a LevelDB key/value store. Entries are
+// stored under monotonic big-endian nanosecond-timestamp keys, so a forward
+// iterator over the full key range yields entries oldest first with no
+// separate index needed.
+type levelBuf struct {
+	db       *leveldb.DB
+	dbPath   string
+	maxLines int
+	maxAge   time.Duration
+	maxBytes int64
+
+	mu      sync.RWMutex
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+
+	// last is the timestamp (ns) of the most recently written key, bumped by
+	// one on collision so keys stay strictly increasing even when two writes
+	// land within the same nanosecond.
+	last int64
+}
+
+// Interface checks.
+var (
+	_ io.Writer = (*levelBuf)(nil)
+	_ Buffer    = (*levelBuf)(nil)
+)
+
+// newLevelDBBuffer returns a LevelDB-backed Buffer. At least one of maxLines,
+// maxAge or maxBytes must be non-zero, otherwise an error is returned.
+func newLevelDBBuffer(maxLines int, maxAge time.Duration, maxBytes int64, dbPath string) (Buffer, error) {
+	if maxLines == 0 && maxAge == 0 && maxBytes == 0 {
+		return nil, fmt.Errorf("logbuf: at least one of maxLines, maxAge or maxBytes must be non-zero")
+	}
+
+	db, err := leveldb.OpenFile(dbPath, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &levelBuf{
+		db:       db,
+		dbPath:   dbPath,
+		maxLines: maxLines,
+		maxAge:   maxAge,
+		maxBytes: maxBytes,
+		closeCh:  make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.retentionLoop()
+
+	return b, nil
+}
+
+// nextKeyLocked returns a strictly increasing big-endian nanosecond-timestamp
+// key. Callers must hold b.mu.
+func (b *levelBuf) nextKeyLocked() []byte {
+	now := time.Now().UnixNano()
+	if now <= b.last {
+		now = b.last + 1
+	}
+	b.last = now
+
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(now))
+	return key
+}
+
+// Write implements Buffer and io.Writer.
+// Inserts the provided bytes as a single entry under the next timestamp key.
+func (b *levelBuf) Write(p []byte) (int, error) {
+	if err := b.WriteEntry(context.Background(), Entry{Message: string(p)}); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteString implements Buffer.
+// Uses Write by converting the string to bytes.
+func (b *levelBuf) WriteString(entry string) error {
+	_, err := b.Write([]byte(entry))
+	return err
+}
+
+// WriteEntry implements Buffer.
+// Persists e under the next timestamp key, preserving its Level and Attrs.
+func (b *levelBuf) WriteEntry(_ context.Context, e Entry) error {
+	ts := e.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	data, err := json.Marshal(levelRecord{Time: ts, Message: e.Message, Level: e.Level, Attrs: e.Attrs})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	key := b.nextKeyLocked()
+	if err := b.db.Put(key, data, nil); err != nil {
+		return err
+	}
+
+	// Trim inline so MaxLines/MaxAge/MaxBytes are enforced immediately, the
+	// same as the SQLite driver's log_trim trigger, instead of only once per
+	// retentionInterval via the background sweep.
+	b.trimLocked()
+	return nil
+}
+
+// Dump implements Buffer.
+// Returns all currently buffered log entries ordered by their key, i.e.
+// oldest first.
+func (b *levelBuf) Dump() ([]string, error) {
+	entries, err := b.Query(Filter{})
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Message
+	}
+	return out, nil
+}
+
+// Query implements Buffer.
+// Returns entries matching filter ordered by their key, i.e. oldest first.
+func (b *levelBuf) Query(filter Filter) ([]Entry, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	iter := b.db.NewIterator(nil, nil)
+	defer iter.Release()
+
+	var entries []Entry
+	for iter.Next() {
+		var rec levelRecord
+		if err := json.Unmarshal(iter.Value(), &rec); err != nil {
+			return nil, err
+		}
+
+		if filter.MinLevel != nil && rec.Level < *filter.MinLevel {
+			continue
+		}
+		if !filter.Since.IsZero() && rec.Time.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && rec.Time.After(filter.Until) {
+			continue
+		}
+		if !matchAttrs(rec.Attrs, filter.AttrMatch) {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			Time:    rec.Time,
+			Level:   rec.Level,
+			Message: strings.TrimSpace(rec.Message),
+			Attrs:   rec.Attrs,
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// DumpJSON implements Buffer.
+// Streams every currently buffered entry to w as newline-delimited JSON,
+// oldest first.
+func (b *levelBuf) DumpJSON(w io.Writer) error {
+	entries, err := b.Query(Filter{})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush implements Buffer. levelBuf writes synchronously, so there is
+// nothing to flush.
+func (b *levelBuf) Flush() error { return nil }
+
+// Stats implements Buffer. levelBuf has no write queue, so this always
+// returns a zero Stats.
+func (b *levelBuf) Stats() Stats { return Stats{} }
+
+// Snapshot implements Buffer.
+// Writes a gzip-compressed NDJSON archive of every currently buffered entry
+// to w.
+func (b *levelBuf) Snapshot(w io.Writer) error {
+	return snapshotViaDumpJSON(b, w)
+}
+
+// Compact implements Buffer.
+// Runs a full-range compaction to reclaim space freed by trim and Clear.
+func (b *levelBuf) Compact() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.db.CompactRange(util.Range{})
+}
+
+// Close stops the retention goroutine and closes the underlying database. It
+// is safe to call multiple times.
+func (b *levelBuf) Close() {
+	b.mu.Lock()
+	if b.closeCh != nil {
+		close(b.closeCh)
+		b.closeCh = nil
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.db != nil {
+		_ = b.db.Close()
+		b.db = nil
+	}
+}
+
+// Clear removes all currently persisted entries.
+func (b *levelBuf) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	iter := b.db.NewIterator(nil, nil)
+	batch := new(leveldb.Batch)
+	for iter.Next() {
+		batch.Delete(append([]byte(nil), iter.Key()...))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return b.db.Write(batch, nil)
+}
+
+// retentionLoop periodically trims entries past maxAge or beyond maxLines
+// until Close is called.
+func (b *levelBuf) retentionLoop() {
+	defer b.wg.Done()
+
+	// Read once: Close sets b.closeCh to nil after closing it, and a select
+	// on a nil channel never fires.
+	closeCh := b.closeCh
+
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closeCh:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			b.trimLocked()
+			b.mu.Unlock()
+		}
+	}
+}
+
+// trimLocked seeks to the oldest keys and deletes anything older than
+// maxAge, then deletes any excess beyond the newest maxLines entries, then
+// deletes the oldest entries beyond the newest maxBytes worth of entries.
+// Callers must hold b.mu; it runs both inline on every write and from
+// retentionLoop as a backstop in case a write path is ever added that skips
+// it.
+func (b *levelBuf) trimLocked() {
+	if b.maxAge > 0 {
+		cutoffKey := make([]byte, 8)
+		binary.BigEndian.PutUint64(cutoffKey, uint64(time.Now().Add(-b.maxAge).UnixNano()))
+
+		iter := b.db.NewIterator(&util.Range{Limit: cutoffKey}, nil)
+		batch := new(leveldb.Batch)
+		for iter.Next() {
+			batch.Delete(append([]byte(nil), iter.Key()...))
+		}
+		iter.Release()
+		if batch.Len() > 0 {
+			_ = b.db.Write(batch, nil)
+		}
+	}
+
+	if b.maxLines > 0 {
+		iter := b.db.NewIterator(nil, nil)
+		var keys [][]byte
+		for iter.Next() {
+			keys = append(keys, append([]byte(nil), iter.Key()...))
+		}
+		iter.Release()
+
+		if excess := len(keys) - b.maxLines; excess > 0 {
+			batch := new(leveldb.Batch)
+			for _, k := range keys[:excess] {
+				batch.Delete(k)
+			}
+			_ = b.db.Write(batch, nil)
+		}
+	}
+
+	if b.maxBytes > 0 {
+		iter := b.db.NewIterator(nil, nil)
+		batch := new(leveldb.Batch)
+		var total int64
+		exceeded := false
+		for ok := iter.Last(); ok; ok = iter.Prev() {
+			// The newest key is always kept, even if it alone exceeds
+			// maxBytes, so a single oversized entry can never wipe the
+			// buffer. Once the running total does exceed maxBytes, every
+			// older key is deleted too.
+			if exceeded {
+				batch.Delete(append([]byte(nil), iter.Key()...))
+				continue
+			}
+			size := int64(len(iter.Value()))
+			if total > 0 && total+size > b.maxBytes {
+				exceeded = true
+				batch.Delete(append([]byte(nil), iter.Key()...))
+				continue
+			}
+			total += size
+		}
+		iter.Release()
+		if batch.Len() > 0 {
+			_ = b.db.Write(batch, nil)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package logbuf
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelDBWriteAndDump(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.leveldb")
+	lb, err := NewWithConfig(Config{Driver: DriverLevelDB, DBPath: fp, MaxLines: 10})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	want := []string{"first entry", "second entry"}
+	for _, e := range want {
+		require.NoError(t, lb.WriteString(e))
+	}
+
+	got, err := lb.Dump()
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestLevelDBMaxLinesRetention(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.leveldb")
+	lb, err := NewWithConfig(Config{Driver: DriverLevelDB, DBPath: fp, MaxLines: 2})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	for _, e := range []string{"a", "b", "c"} {
+		require.NoError(t, lb.WriteString(e))
+	}
+
+	// Retention for this driver is enforced inline on every write (not just
+	// by the background sweep), so the excess is gone immediately.
+	got, err := lb.Dump()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, got)
+}
+
+func TestLevelDBClear(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.leveldb")
+	lb, err := NewWithConfig(Config{Driver: DriverLevelDB, DBPath: fp, MaxLines: 10})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	require.NoError(t, lb.WriteString("to be removed"))
+	require.NoError(t, lb.Clear())
+
+	entries, err := lb.Dump()
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+}
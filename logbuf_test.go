@@ -1,6 +1,11 @@
 package logbuf
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"testing"
@@ -63,3 +68,148 @@ func TestClear(t *testing.T) {
 	_, err = os.Stat(fp)
 	require.NoError(t, err, "database file should be recreated after Dump")
 }
+
+func TestWriteEntryAndQuery(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	lb, err := NewSQliteBuffer(10, 0, fp)
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	require.NoError(t, lb.WriteEntry(context.Background(), Entry{
+		Level:   slog.LevelDebug,
+		Message: "debug entry",
+	}))
+	require.NoError(t, lb.WriteEntry(context.Background(), Entry{
+		Level:   slog.LevelInfo,
+		Message: "info entry",
+		Attrs:   map[string]any{"user": "alice"},
+	}))
+
+	got, err := lb.Query(Filter{MinLevel: MinLevel(slog.LevelInfo)})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "info entry", got[0].Message)
+	require.Equal(t, "alice", got[0].Attrs["user"])
+
+	matched, err := lb.Query(Filter{AttrMatch: map[string]any{"user": "alice"}})
+	require.NoError(t, err)
+	require.Len(t, matched, 1)
+
+	unmatched, err := lb.Query(Filter{AttrMatch: map[string]any{"user": "bob"}})
+	require.NoError(t, err)
+	require.Len(t, unmatched, 0)
+}
+
+// TestAttrMatchNumericValue guards against a regression where a numeric
+// AttrMatch value never matched on the SQLite and LevelDB backends: attrs
+// round-trip through json.Marshal/Unmarshal, which turns an int into a
+// float64, so matchAttrs's plain != comparison always failed even though the
+// in-memory backend (no JSON round trip) matched fine.
+func TestAttrMatchNumericValue(t *testing.T) {
+	for _, driver := range []Driver{DriverSQLite, DriverLevelDB, DriverMemory} {
+		t.Run(string(driver), func(t *testing.T) {
+			cfg := Config{Driver: driver, MaxLines: 10}
+			if driver != DriverMemory {
+				cfg.DBPath = filepath.Join(t.TempDir(), "lb.db")
+			}
+			lb, err := NewWithConfig(cfg)
+			require.NoError(t, err)
+			defer lb.Clear()
+
+			require.NoError(t, lb.WriteEntry(context.Background(), Entry{
+				Message: "request handled",
+				Attrs:   map[string]any{"status": 200},
+			}))
+
+			got, err := lb.Query(Filter{AttrMatch: map[string]any{"status": 200}})
+			require.NoError(t, err)
+			require.Len(t, got, 1)
+		})
+	}
+}
+
+// TestDebugEntryIsUnfiltered guards against a regression where Filter{}'s
+// zero-value MinLevel (a *slog.Level of nil) was compared as though it meant
+// slog.LevelInfo, silently dropping Debug entries from DumpJSON/Snapshot/
+// Query(Filter{}) even though they document streaming "every" entry.
+func TestDebugEntryIsUnfiltered(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	lb, err := NewSQliteBuffer(10, 0, fp)
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	require.NoError(t, lb.WriteEntry(context.Background(), Entry{
+		Level:   slog.LevelDebug,
+		Message: "debug entry",
+	}))
+
+	got, err := lb.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "debug entry", got[0].Message)
+
+	var buf bytes.Buffer
+	require.NoError(t, lb.DumpJSON(&buf))
+	var dumped Entry
+	require.NoError(t, json.NewDecoder(&buf).Decode(&dumped))
+	require.Equal(t, "debug entry", dumped.Message)
+
+	var snap bytes.Buffer
+	require.NoError(t, lb.Snapshot(&snap))
+	gz, err := gzip.NewReader(&snap)
+	require.NoError(t, err)
+	defer gz.Close()
+	var snapshotted Entry
+	require.NoError(t, json.NewDecoder(gz).Decode(&snapshotted))
+	require.Equal(t, "debug entry", snapshotted.Message)
+}
+
+func TestMaxBytesRetention(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	lb, err := NewWithConfig(Config{DBPath: fp, MaxBytes: 5})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	for _, e := range []string{"aaa", "bbb", "ccc"} {
+		require.NoError(t, lb.WriteString(e))
+	}
+
+	got, err := lb.Dump()
+	require.NoError(t, err)
+	require.Equal(t, []string{"ccc"}, got)
+}
+
+func TestMaxBytesKeepsNewestEntryEvenIfOversized(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	lb, err := NewWithConfig(Config{DBPath: fp, MaxBytes: 5})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	require.NoError(t, lb.WriteString("this entry is much longer than five bytes"))
+
+	got, err := lb.Dump()
+	require.NoError(t, err)
+	require.Equal(t, []string{"this entry is much longer than five bytes"}, got)
+}
+
+func TestSnapshotAndCompact(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	lb, err := NewSQliteBuffer(10, 0, fp)
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	require.NoError(t, lb.WriteString("snapshotted entry"))
+
+	var buf bytes.Buffer
+	require.NoError(t, lb.Snapshot(&buf))
+
+	gz, err := gzip.NewReader(&buf)
+	require.NoError(t, err)
+	defer gz.Close()
+
+	var e Entry
+	require.NoError(t, json.NewDecoder(gz).Decode(&e))
+	require.Equal(t, "snapshotted entry", e.Message)
+
+	require.NoError(t, lb.Compact())
+}
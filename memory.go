@@ -0,0 +1,213 @@
+// Package logbuf / memory.go contains a non-persistent, in-process
+// implementation of the Buffer interface.
+package logbuf
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memoryBuf implements Buffer on top of an in-process slice. Nothing is
+// written to disk, so entries do not survive process restarts; this is
+// mainly useful for tests and short-lived processes.
+type memoryBuf struct {
+	mu       sync.RWMutex
+	entries  []memoryEntry
+	maxLines int
+	maxAge   time.Duration
+	maxBytes int64
+}
+
+type memoryEntry struct {
+	ts    time.Time
+	line  string
+	level slog.Level
+	attrs map[string]any
+}
+
+// Interface checks.
+var (
+	_ io.Writer = (*memoryBuf)(nil)
+	_ Buffer    = (*memoryBuf)(nil)
+)
+
+// newMemoryBuffer returns an in-memory Buffer. At least one of maxLines,
+// maxAge or maxBytes must be non-zero, otherwise an error is returned.
+func newMemoryBuffer(maxLines int, maxAge time.Duration, maxBytes int64) (Buffer, error) {
+	if maxLines == 0 && maxAge == 0 && maxBytes == 0 {
+		return nil, fmt.Errorf("logbuf: at least one of maxLines, maxAge or maxBytes must be non-zero")
+	}
+
+	return &memoryBuf{maxLines: maxLines, maxAge: maxAge, maxBytes: maxBytes}, nil
+}
+
+// Write implements Buffer and io.Writer.
+// Appends the provided bytes as a single entry, then applies retention.
+func (b *memoryBuf) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, memoryEntry{ts: time.Now(), line: strings.TrimSpace(string(p))})
+	b.trimLocked()
+	return len(p), nil
+}
+
+// WriteString implements Buffer.
+// Uses Write by converting the string to bytes.
+func (b *memoryBuf) WriteString(entry string) error {
+	_, err := b.Write([]byte(entry))
+	return err
+}
+
+// Dump implements Buffer.
+// Returns all currently buffered log entries ordered by their time of
+// insertion, oldest first.
+func (b *memoryBuf) Dump() ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	entries := make([]string, len(b.entries))
+	for i, e := range b.entries {
+		entries[i] = e.line
+	}
+	return entries, nil
+}
+
+// WriteEntry implements Buffer.
+// Appends e, preserving its Level and Attrs, then applies retention.
+func (b *memoryBuf) WriteEntry(_ context.Context, e Entry) error {
+	ts := e.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries = append(b.entries, memoryEntry{
+		ts:    ts,
+		line:  strings.TrimSpace(e.Message),
+		level: e.Level,
+		attrs: e.Attrs,
+	})
+	b.trimLocked()
+	return nil
+}
+
+// Query implements Buffer.
+// Returns entries matching filter ordered by their time of insertion, oldest
+// first.
+func (b *memoryBuf) Query(filter Filter) ([]Entry, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var entries []Entry
+	for _, me := range b.entries {
+		if filter.MinLevel != nil && me.level < *filter.MinLevel {
+			continue
+		}
+		if !filter.Since.IsZero() && me.ts.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && me.ts.After(filter.Until) {
+			continue
+		}
+		if !matchAttrs(me.attrs, filter.AttrMatch) {
+			continue
+		}
+		entries = append(entries, Entry{Time: me.ts, Level: me.level, Message: me.line, Attrs: me.attrs})
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// DumpJSON implements Buffer.
+// Streams every currently buffered entry to w as newline-delimited JSON,
+// oldest first.
+func (b *memoryBuf) DumpJSON(w io.Writer) error {
+	entries, err := b.Query(Filter{})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush implements Buffer. memoryBuf writes synchronously, so there is
+// nothing to flush.
+func (b *memoryBuf) Flush() error { return nil }
+
+// Stats implements Buffer. memoryBuf has no write queue, so this always
+// returns a zero Stats.
+func (b *memoryBuf) Stats() Stats { return Stats{} }
+
+// Snapshot implements Buffer.
+// Writes a gzip-compressed NDJSON archive of every currently buffered entry
+// to w.
+func (b *memoryBuf) Snapshot(w io.Writer) error {
+	return snapshotViaDumpJSON(b, w)
+}
+
+// Compact implements Buffer. memoryBuf keeps no freed space to reclaim, so
+// this is a no-op.
+func (b *memoryBuf) Compact() error { return nil }
+
+// Close implements Buffer. There is no underlying resource to release.
+func (b *memoryBuf) Close() {}
+
+// Clear implements Buffer.
+// Drops all currently buffered entries.
+func (b *memoryBuf) Clear() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries = nil
+	return nil
+}
+
+// trimLocked enforces maxAge, maxLines and maxBytes. Callers must hold b.mu.
+func (b *memoryBuf) trimLocked() {
+	if b.maxAge > 0 {
+		cutoff := time.Now().Add(-b.maxAge)
+		i := 0
+		for i < len(b.entries) && b.entries[i].ts.Before(cutoff) {
+			i++
+		}
+		b.entries = b.entries[i:]
+	}
+
+	if b.maxLines > 0 && len(b.entries) > b.maxLines {
+		b.entries = b.entries[len(b.entries)-b.maxLines:]
+	}
+
+	if b.maxBytes > 0 {
+		var total int64
+		i := len(b.entries)
+		for i > 0 {
+			size := int64(len(b.entries[i-1].line))
+			// The newest entry (i == len(b.entries)) is always kept, even if
+			// it alone exceeds maxBytes, so a single oversized entry can
+			// never empty the buffer.
+			if i < len(b.entries) && total+size > b.maxBytes {
+				break
+			}
+			total += size
+			i--
+		}
+		b.entries = b.entries[i:]
+	}
+}
@@ -0,0 +1,49 @@
+package logbuf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryWriteAndDump(t *testing.T) {
+	lb, err := NewWithConfig(Config{Driver: DriverMemory, MaxLines: 10})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	want := []string{"first entry", "second entry"}
+	for _, e := range want {
+		require.NoError(t, lb.WriteString(e))
+	}
+
+	got, err := lb.Dump()
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
+
+func TestMemoryMaxLinesRetention(t *testing.T) {
+	lb, err := NewWithConfig(Config{Driver: DriverMemory, MaxLines: 2})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	for _, e := range []string{"a", "b", "c"} {
+		require.NoError(t, lb.WriteString(e))
+	}
+
+	got, err := lb.Dump()
+	require.NoError(t, err)
+	require.Equal(t, []string{"b", "c"}, got)
+}
+
+func TestMemoryClear(t *testing.T) {
+	lb, err := NewWithConfig(Config{Driver: DriverMemory, MaxLines: 10})
+	require.NoError(t, err)
+	defer lb.Clear()
+
+	require.NoError(t, lb.WriteString("to be removed"))
+	require.NoError(t, lb.Clear())
+
+	entries, err := lb.Dump()
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+}
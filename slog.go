@@ -0,0 +1,110 @@
+// Package logbuf / slog.go ships a ready-made slog.Handler so callers don't
+// have to hand-roll the buf-plus-forwarding boilerplate shown in this
+// package's examples.
+package logbuf
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogHandler is a slog.Handler that writes every record to buf via
+// WriteEntry and forwards records at or above forwardLevel to next.
+type slogHandler struct {
+	buf          Buffer
+	forwardLevel slog.Level
+	next         slog.Handler
+	attrs        []slog.Attr
+
+	// groupPrefix is the dot-joined path of currently open WithGroup names,
+	// or "" if none are open. Entry.Attrs is a flat map, so keys added under
+	// an open group are qualified with this prefix to avoid two groups that
+	// reuse a key name silently overwriting each other.
+	groupPrefix string
+}
+
+// Interface check.
+var _ slog.Handler = (*slogHandler)(nil)
+
+// NewSlogHandler returns a slog.Handler that writes every record it receives
+// to buf via WriteEntry, and additionally forwards records at or above
+// forwardLevel to next. next may be nil, in which case nothing is forwarded
+// and every record is only buffered.
+func NewSlogHandler(buf Buffer, forwardLevel slog.Level, next slog.Handler) slog.Handler {
+	return &slogHandler{buf: buf, forwardLevel: forwardLevel, next: next}
+}
+
+// Enabled implements slog.Handler.
+// Always returns true so every record reaches Handle and is buffered; next's
+// own Enabled only gates whether the record is additionally forwarded.
+func (h *slogHandler) Enabled(context.Context, slog.Level) bool {
+	return true
+}
+
+// Handle implements slog.Handler.
+// Writes r to buf, then forwards it to next if its level meets forwardLevel.
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := make(map[string]any, len(h.attrs)+r.NumAttrs())
+	for _, a := range h.attrs {
+		attrs[a.Key] = a.Value.Any()
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[h.qualify(a.Key)] = a.Value.Any()
+		return true
+	})
+	if len(attrs) == 0 {
+		attrs = nil
+	}
+
+	if err := h.buf.WriteEntry(ctx, Entry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   attrs,
+	}); err != nil {
+		return err
+	}
+
+	if h.next != nil && r.Level >= h.forwardLevel && h.next.Enabled(ctx, r.Level) {
+		return h.next.Handle(ctx, r)
+	}
+	return nil
+}
+
+// WithAttrs implements slog.Handler.
+// Keys are qualified with any currently open WithGroup path before being
+// merged, so attrs added under different groups never collide.
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := h.next
+	if next != nil {
+		next = next.WithAttrs(attrs)
+	}
+
+	qualified := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		qualified[i] = slog.Attr{Key: h.qualify(a.Key), Value: a.Value}
+	}
+	merged := append(append([]slog.Attr(nil), h.attrs...), qualified...)
+	return &slogHandler{buf: h.buf, forwardLevel: h.forwardLevel, next: next, attrs: merged, groupPrefix: h.groupPrefix}
+}
+
+// WithGroup implements slog.Handler.
+// Opens name as a group: attrs and record attrs added afterwards are
+// qualified with it when flattened into Entry.Attrs by qualify.
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	next := h.next
+	if next != nil {
+		next = next.WithGroup(name)
+	}
+	return &slogHandler{buf: h.buf, forwardLevel: h.forwardLevel, next: next, attrs: h.attrs, groupPrefix: h.qualify(name)}
+}
+
+// qualify prefixes key with h.groupPrefix, if any currently open group, so
+// that flattening into Entry.Attrs can't let two groups' same-named keys
+// collide.
+func (h *slogHandler) qualify(key string) string {
+	if h.groupPrefix == "" {
+		return key
+	}
+	return h.groupPrefix + "." + key
+}
@@ -0,0 +1,42 @@
+package logbuf
+
+import (
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlogHandlerWithGroupQualifiesKeys(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	buf, err := NewSQliteBuffer(10, 0, fp)
+	require.NoError(t, err)
+	defer buf.Clear()
+
+	h := NewSlogHandler(buf, slog.LevelInfo, nil)
+	logger := slog.New(h).With("id", "outer").WithGroup("req").With("id", "inner")
+	logger.Info("handled request")
+
+	got, err := buf.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "outer", got[0].Attrs["id"])
+	require.Equal(t, "inner", got[0].Attrs["req.id"])
+}
+
+func TestSlogHandlerQualifiesRecordAttrsUnderOpenGroup(t *testing.T) {
+	fp := filepath.Join(t.TempDir(), "lb.db")
+	buf, err := NewSQliteBuffer(10, 0, fp)
+	require.NoError(t, err)
+	defer buf.Clear()
+
+	h := NewSlogHandler(buf, slog.LevelInfo, nil)
+	logger := slog.New(h).WithGroup("req")
+	logger.Info("handled request", "id", "inline")
+
+	got, err := buf.Query(Filter{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, "inline", got[0].Attrs["req.id"])
+}
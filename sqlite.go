@@ -2,20 +2,21 @@
 package logbuf
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"strings"
 	"sync"
 	"time"
-
-	_ "github.com/mattn/go-sqlite3"
 )
 
 const (
-	writeStmt = `INSERT INTO log(entry) VALUES (?);`
-	dumpStmt  = `SELECT entry FROM log ORDER BY timestamp;`
+	writeEntryStmt = `INSERT INTO log(timestamp, entry, level, attrs) VALUES (?, ?, ?, ?);`
+	dumpStmt       = `SELECT entry FROM log ORDER BY timestamp;`
 )
 
 // Interface checks.
@@ -31,18 +32,25 @@ var setupSQL = []string{
         timestamp INTEGER NOT NULL DEFAULT (
             CAST((julianday('now') - 2440587.5) * 86400000000000 AS INTEGER)
         ),
-        entry BLOB NOT NULL
+        entry BLOB NOT NULL,
+        level INTEGER NOT NULL DEFAULT 0,
+        attrs JSON
     );`,
 
 	`CREATE TABLE IF NOT EXISTS log_settings (
         id        INTEGER PRIMARY KEY CHECK(id = 1),
         maxAge_ns INTEGER NOT NULL,
-        maxLines  INTEGER NOT NULL
+        maxLines  INTEGER NOT NULL,
+        maxBytes  INTEGER NOT NULL DEFAULT 0
     );`,
 
 	`CREATE INDEX IF NOT EXISTS idx_log_ts ON log(timestamp);`,
 
-	`CREATE TRIGGER IF NOT EXISTS log_trim
+	// Dropped and recreated on every open so that databases created before
+	// the maxBytes clause was added pick up the new trigger body.
+	`DROP TRIGGER IF EXISTS log_trim;`,
+
+	`CREATE TRIGGER log_trim
      AFTER INSERT ON log
      BEGIN
          DELETE FROM log
@@ -54,40 +62,90 @@ var setupSQL = []string{
            AND rowid NOT IN (
                SELECT rowid FROM log ORDER BY timestamp DESC LIMIT (SELECT maxLines FROM log_settings WHERE id = 1)
            );
+
+         -- The newest row is always kept, even if it alone exceeds maxBytes,
+         -- so a single oversized entry can never wipe the table; every older
+         -- row is dropped whose own bytes plus those of all newer rows exceed
+         -- the budget.
+         DELETE FROM log
+         WHERE (SELECT maxBytes FROM log_settings WHERE id = 1) > 0
+           AND rowid IN (
+               SELECT rowid FROM (
+                   SELECT
+                       rowid,
+                       ROW_NUMBER() OVER (ORDER BY timestamp DESC) AS rn,
+                       LENGTH(entry) + COALESCE(SUM(LENGTH(entry)) OVER (
+                           ORDER BY timestamp DESC
+                           ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+                       ), 0) AS running_bytes
+                   FROM log
+               )
+               WHERE rn > 1
+                 AND running_bytes > (SELECT maxBytes FROM log_settings WHERE id = 1)
+           );
      END;`,
 }
 
-// sqliteBuf implements LogBuf.
+// sqliteBuf implements Buffer.
 type sqliteBuf struct {
 	db       *sql.DB
 	dbPath   string
 	maxLines int
 	maxAge   time.Duration
+	maxBytes int64
 	mu       sync.RWMutex
+
+	// batch is non-nil when Write/WriteEntry queue rows for the background
+	// flusher instead of inserting synchronously. See sqlite_batch.go.
+	batch       *BatchConfig
+	queue       chan batchRow
+	flushReq    chan chan error
+	flusherDone chan struct{}
+	closeQueue  sync.Once
+	stats       batchStats
+
+	// closeMu guards closed against concurrent enqueue/Flush calls: Close
+	// takes it for writing before closing b.queue, and enqueue/Flush take it
+	// for reading around their sends, so a send can never race a close.
+	closeMu sync.RWMutex
+	closed  bool
 }
 
-// NewSQliteBuffer returns an SQLite-backed logbuf.Buffer. At least one of maxLines or
-// maxAge must be non-zero, otherwise an error is returned.
+// NewSQliteBuffer returns an SQLite-backed logbuf.Buffer that writes
+// synchronously. At least one of maxLines or maxAge must be non-zero,
+// otherwise an error is returned.
 func NewSQliteBuffer(maxLines int, maxAge time.Duration, dbPath string) (Buffer, error) {
-	if maxLines == 0 && maxAge == 0 {
-		return nil, fmt.Errorf("logbuf: at least one of maxLines or maxAge must be non-zero")
+	return newSQLiteBuffer(maxLines, maxAge, 0, dbPath, nil)
+}
+
+// newSQLiteBuffer is the unexported constructor shared by New, NewSQliteBuffer
+// and NewWithConfig(Config{Driver: DriverSQLite}). batch is nil for
+// synchronous, per-row writes; see BatchConfig for the asynchronous mode.
+func newSQLiteBuffer(maxLines int, maxAge time.Duration, maxBytes int64, dbPath string, batch *BatchConfig) (Buffer, error) {
+	if maxLines == 0 && maxAge == 0 && maxBytes == 0 {
+		return nil, fmt.Errorf("logbuf: at least one of maxLines, maxAge or maxBytes must be non-zero")
 	}
 
 	lb := &sqliteBuf{
 		dbPath:   dbPath,
 		maxLines: maxLines,
 		maxAge:   maxAge,
+		maxBytes: maxBytes,
 	}
 	if err := lb.open(); err != nil {
 		return nil, err
 	}
+
+	if batch != nil {
+		lb.startBatching(batch)
+	}
 	return lb, nil
 }
 
 // open (re)creates the underlying database connection and executes all setup
 // SQL including the parameterisation of the retention trigger.
 func (b *sqliteBuf) open() error {
-	db, err := sql.Open("sqlite3", b.dbPath)
+	db, err := sql.Open(sqlDriverName, b.dbPath)
 	if err != nil {
 		return err
 	}
@@ -106,10 +164,18 @@ func (b *sqliteBuf) open() error {
 		}
 	}
 
+	if err = migrateLogTable(db); err != nil {
+		return err
+	}
+	if err = migrateLogSettingsTable(db); err != nil {
+		return err
+	}
+
 	if _, err = db.Exec(
-		`INSERT OR REPLACE INTO log_settings(id, maxAge_ns, maxLines) VALUES (1, ?, ?);`,
+		`INSERT OR REPLACE INTO log_settings(id, maxAge_ns, maxLines, maxBytes) VALUES (1, ?, ?, ?);`,
 		b.maxAge.Nanoseconds(),
 		b.maxLines,
+		b.maxBytes,
 	); err != nil {
 		return err
 	}
@@ -118,38 +184,96 @@ func (b *sqliteBuf) open() error {
 	return nil
 }
 
-// Write implements LogBuf and io.Writer.
-// Inserts the provided bytes as a single entry into the buffer.
-func (b *sqliteBuf) Write(p []byte) (int, error) {
-	// First lock DB to prevent Close and try to write.
-	// If DB is missing run ensureOpen and try again.
-	for {
-		b.mu.RLock()
-		db := b.db
-		if db != nil {
-			_, err := db.Exec(writeStmt, p)
-			b.mu.RUnlock()
-			if err != nil {
-				return 0, err
-			}
-			return len(p), nil
+// migrateLogTable adds the level/attrs columns to log if the database was
+// created before this package stored structured entries. CREATE TABLE IF NOT
+// EXISTS in setupSQL only applies to brand-new databases, so pre-existing
+// ones need an explicit ALTER TABLE.
+func migrateLogTable(db *sql.DB) error {
+	rows, err := db.Query(`PRAGMA table_info(log);`)
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]bool)
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull, pk int
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
 		}
-		b.mu.RUnlock()
+		have[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
 
-		if err := b.ensureOpen(); err != nil {
-			return 0, err
+	if !have["level"] {
+		if _, err := db.Exec(`ALTER TABLE log ADD COLUMN level INTEGER NOT NULL DEFAULT 0;`); err != nil {
+			return err
+		}
+	}
+	if !have["attrs"] {
+		if _, err := db.Exec(`ALTER TABLE log ADD COLUMN attrs JSON;`); err != nil {
+			return err
 		}
 	}
+	return nil
+}
+
+// migrateLogSettingsTable adds the maxBytes column to log_settings if the
+// database was created before size-based retention was supported.
+func migrateLogSettingsTable(db *sql.DB) error {
+	rows, err := db.Query(`PRAGMA table_info(log_settings);`)
+	if err != nil {
+		return err
+	}
+
+	have := make(map[string]bool)
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull, pk int
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dflt, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		have[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	if !have["maxBytes"] {
+		if _, err := db.Exec(`ALTER TABLE log_settings ADD COLUMN maxBytes INTEGER NOT NULL DEFAULT 0;`); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// WriteString implements LogBuf.
+// Write implements Buffer and io.Writer.
+// Inserts the provided bytes as a single entry into the buffer.
+func (b *sqliteBuf) Write(p []byte) (int, error) {
+	if err := b.writeRow(time.Now().UnixNano(), append([]byte(nil), p...), 0, nil); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// WriteString implements Buffer.
 // Uses Write by converting the string to bytes.
 func (b *sqliteBuf) WriteString(entry string) error {
 	_, err := b.Write([]byte(entry))
 	return err
 }
 
-// Dump implements LogBuf.
+// Dump implements Buffer.
 // Returns all currently buffered log entries ordered by their time of insertion, oldest first.
 func (b *sqliteBuf) Dump() ([]string, error) {
 	// First lock DB to prevent Close and try to dump.
@@ -194,8 +318,185 @@ func (b *sqliteBuf) Dump() ([]string, error) {
 	return entries, rows.Err()
 }
 
+// WriteEntry implements Buffer.
+// Persists e with its Level and Attrs as separate columns instead of
+// flattening them into the entry BLOB.
+func (b *sqliteBuf) WriteEntry(ctx context.Context, e Entry) error {
+	attrs, err := json.Marshal(e.Attrs)
+	if err != nil {
+		return err
+	}
+
+	ts := e.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+
+	return b.writeRow(ts.UnixNano(), []byte(e.Message), int64(e.Level), attrs)
+}
+
+// writeRow inserts a single row, either synchronously or by handing it to the
+// background flusher, depending on whether b.batch is set.
+func (b *sqliteBuf) writeRow(ts int64, entry []byte, level int64, attrs []byte) error {
+	if b.batch != nil {
+		return b.enqueue(batchRow{ts: ts, entry: entry, level: level, attrs: attrs})
+	}
+
+	for {
+		b.mu.RLock()
+		db := b.db
+		if db != nil {
+			_, err := db.Exec(writeEntryStmt, ts, entry, level, attrs)
+			b.mu.RUnlock()
+			return err
+		}
+		b.mu.RUnlock()
+
+		if err := b.ensureOpen(); err != nil {
+			return err
+		}
+	}
+}
+
+// Query implements Buffer.
+// Returns entries matching filter ordered by Entry.Time (the time of insertion
+// for Write), oldest first. AttrMatch and Limit are applied after reading the rows, since attrs
+// is stored as an opaque JSON column.
+func (b *sqliteBuf) Query(filter Filter) ([]Entry, error) {
+	query := `SELECT timestamp, entry, level, attrs FROM log`
+	var args []any
+	var conds []string
+
+	if filter.MinLevel != nil {
+		conds = append(conds, `level >= ?`)
+		args = append(args, int64(*filter.MinLevel))
+	}
+	if !filter.Since.IsZero() {
+		conds = append(conds, `timestamp >= ?`)
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		conds = append(conds, `timestamp <= ?`)
+		args = append(args, filter.Until.UnixNano())
+	}
+	if len(conds) > 0 {
+		query += ` WHERE ` + strings.Join(conds, " AND ")
+	}
+	query += ` ORDER BY timestamp;`
+
+	var rows *sql.Rows
+	var err error
+	for {
+		b.mu.RLock()
+		db := b.db
+		if db != nil {
+			rows, err = db.Query(query, args...)
+			b.mu.RUnlock()
+			if err != nil {
+				return nil, err
+			}
+			defer rows.Close()
+			break
+		}
+		b.mu.RUnlock()
+
+		if err := b.ensureOpen(); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []Entry
+	for rows.Next() {
+		var ts, level int64
+		var msg []byte
+		var attrsJSON sql.NullString
+		if err := rows.Scan(&ts, &msg, &level, &attrsJSON); err != nil {
+			return nil, err
+		}
+
+		var attrs map[string]any
+		if attrsJSON.Valid && attrsJSON.String != "" {
+			if err := json.Unmarshal([]byte(attrsJSON.String), &attrs); err != nil {
+				return nil, err
+			}
+		}
+		if !matchAttrs(attrs, filter.AttrMatch) {
+			continue
+		}
+
+		entries = append(entries, Entry{
+			Time:    time.Unix(0, ts),
+			Level:   slog.Level(level),
+			Message: strings.TrimSpace(string(msg)),
+			Attrs:   attrs,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if filter.Limit > 0 && len(entries) > filter.Limit {
+		entries = entries[len(entries)-filter.Limit:]
+	}
+	return entries, nil
+}
+
+// DumpJSON implements Buffer.
+// Streams every currently buffered entry to w as newline-delimited JSON,
+// oldest first.
+func (b *sqliteBuf) DumpJSON(w io.Writer) error {
+	entries, err := b.Query(Filter{})
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot implements Buffer.
+// Writes a gzip-compressed NDJSON archive of every currently buffered entry
+// to w.
+func (b *sqliteBuf) Snapshot(w io.Writer) error {
+	return snapshotViaDumpJSON(b, w)
+}
+
+// Compact implements Buffer.
+// Runs VACUUM to reclaim space freed by the retention trigger and Clear.
+func (b *sqliteBuf) Compact() error {
+	for {
+		b.mu.RLock()
+		db := b.db
+		if db != nil {
+			_, err := db.Exec(`VACUUM;`)
+			b.mu.RUnlock()
+			return err
+		}
+		b.mu.RUnlock()
+
+		if err := b.ensureOpen(); err != nil {
+			return err
+		}
+	}
+}
+
 // Close closes the underlying database. It is safe to call multiple times.
+// If batched writes are enabled, Close first drains the queue so no enqueued
+// entry is lost.
 func (b *sqliteBuf) Close() {
+	if b.batch != nil {
+		b.closeMu.Lock()
+		b.closed = true
+		b.closeQueue.Do(func() { close(b.queue) })
+		b.closeMu.Unlock()
+		<-b.flusherDone
+	}
+
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	if b.db != nil {
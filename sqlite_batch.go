@@ -0,0 +1,232 @@
+// Package logbuf / sqlite_batch.go implements the optional BatchConfig mode
+// for sqliteBuf: instead of a synchronous INSERT per Write, rows are queued
+// and a single background goroutine flushes them together in one
+// transaction, which is substantially cheaper under WAL-mode SQLite.
+package logbuf
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// batchRow is a single not-yet-flushed row, already shaped for writeEntryStmt.
+type batchRow struct {
+	ts    int64
+	entry []byte
+	level int64
+	attrs []byte
+}
+
+// batchStats holds the atomic counters backing sqliteBuf.Stats.
+type batchStats struct {
+	enqueued atomic.Int64
+	dropped  atomic.Int64
+	flushed  atomic.Int64
+}
+
+// startBatching normalises batch's zero values, wires up the queue, and
+// starts the flusher goroutine.
+func (b *sqliteBuf) startBatching(batch *BatchConfig) {
+	queueSize := batch.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1
+	}
+	flushInterval := batch.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	b.batch = &BatchConfig{
+		MaxBatch:      batch.MaxBatch,
+		FlushInterval: flushInterval,
+		QueueSize:     queueSize,
+		DropPolicy:    batch.DropPolicy,
+	}
+	b.queue = make(chan batchRow, queueSize)
+	b.flushReq = make(chan chan error)
+	b.flusherDone = make(chan struct{})
+
+	go b.runFlusher()
+}
+
+// enqueue adds row to the write queue, applying b.batch.DropPolicy if it is
+// full. It holds b.closeMu for reading so that a concurrent Close cannot
+// close b.queue out from under a send in progress.
+func (b *sqliteBuf) enqueue(row batchRow) error {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closed {
+		return fmt.Errorf("logbuf: buffer is closed")
+	}
+
+	switch b.batch.DropPolicy {
+	case DropNewest:
+		select {
+		case b.queue <- row:
+			b.stats.enqueued.Add(1)
+		default:
+			b.stats.dropped.Add(1)
+		}
+		return nil
+
+	case Block:
+		b.queue <- row
+		b.stats.enqueued.Add(1)
+		return nil
+
+	default: // DropOldest
+		for {
+			select {
+			case b.queue <- row:
+				b.stats.enqueued.Add(1)
+				return nil
+			default:
+			}
+			select {
+			case <-b.queue:
+				b.stats.dropped.Add(1)
+			default:
+			}
+		}
+	}
+}
+
+// runFlusher drains the queue into the database every MaxBatch rows or
+// FlushInterval, whichever comes first, until the queue is closed.
+func (b *sqliteBuf) runFlusher() {
+	defer close(b.flusherDone)
+
+	ticker := time.NewTicker(b.batch.FlushInterval)
+	defer ticker.Stop()
+
+	var pending []batchRow
+	// flushErr is the first failed flush since the last Flush call, which
+	// Flush reports so its caller learns that rows were not committed.
+	var flushErr error
+	flush := func() {
+		if len(pending) == 0 {
+			return
+		}
+		if err := b.flushRows(pending); err == nil {
+			b.stats.flushed.Add(int64(len(pending)))
+		} else if flushErr == nil {
+			flushErr = err
+		}
+		pending = pending[:0]
+	}
+
+	for {
+		select {
+		case row, ok := <-b.queue:
+			if !ok {
+				flush()
+				return
+			}
+			pending = append(pending, row)
+			if b.batch.MaxBatch > 0 && len(pending) >= b.batch.MaxBatch {
+				flush()
+			}
+
+		case <-ticker.C:
+			flush()
+
+		case reply := <-b.flushReq:
+			// A row can already be sitting in b.queue's buffer, enqueued
+			// before this flush was requested but not yet picked up by the
+			// case above (select makes no guarantee which ready case runs).
+			// Drain whatever is already buffered before flushing so Flush
+			// never returns having missed it.
+			for drained := true; drained; {
+				select {
+				case row, ok := <-b.queue:
+					if !ok {
+						flush()
+						reply <- nil
+						return
+					}
+					pending = append(pending, row)
+					if b.batch.MaxBatch > 0 && len(pending) >= b.batch.MaxBatch {
+						flush()
+					}
+				default:
+					drained = false
+				}
+			}
+			flush()
+			reply <- flushErr
+			flushErr = nil
+		}
+	}
+}
+
+// flushRows commits rows to the database in a single transaction.
+func (b *sqliteBuf) flushRows(rows []batchRow) error {
+	b.mu.RLock()
+	db := b.db
+	b.mu.RUnlock()
+	if db == nil {
+		if err := b.ensureOpen(); err != nil {
+			return err
+		}
+		b.mu.RLock()
+		db = b.db
+		b.mu.RUnlock()
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.Prepare(writeEntryStmt)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	for _, r := range rows {
+		if _, err := stmt.Exec(r.ts, r.entry, r.level, r.attrs); err != nil {
+			_ = stmt.Close()
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	_ = stmt.Close()
+	return tx.Commit()
+}
+
+// Flush implements Buffer.
+// Blocks until every row enqueued so far has been flushed, and returns the
+// first error of any flush since the previous Flush call; rows of a failed
+// flush are discarded. It is a no-op when batching is disabled or the buffer
+// has already been closed.
+func (b *sqliteBuf) Flush() error {
+	if b.batch == nil {
+		return nil
+	}
+
+	// Held for the duration of the call, not just the closed check: this
+	// blocks a concurrent Close from closing b.queue (and stopping
+	// runFlusher) until the flusher has replied, so the send below can
+	// never land on a channel nobody is reading anymore.
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+	if b.closed {
+		return nil
+	}
+
+	reply := make(chan error, 1)
+	b.flushReq <- reply
+	return <-reply
+}
+
+// Stats implements Buffer.
+func (b *sqliteBuf) Stats() Stats {
+	return Stats{
+		Enqueued: b.stats.enqueued.Load(),
+		Dropped:  b.stats.dropped.Load(),
+		Flushed:  b.stats.flushed.Load(),
+	}
+}
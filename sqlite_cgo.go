@@ -0,0 +1,15 @@
+//go:build logbuf_cgo
+
+// Package logbuf / sqlite_cgo.go selects mattn/go-sqlite3 as the SQLite
+// driver for sqliteBuf. This is the original driver this package shipped
+// with; it requires cgo and a C toolchain at build time.
+package logbuf
+
+import (
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// sqlDriverName is the database/sql driver name sqliteBuf opens. It is set
+// per build tag so NewSQliteBuffer picks whichever SQLite driver was
+// actually compiled in.
+const sqlDriverName = "sqlite3"
@@ -0,0 +1,17 @@
+//go:build !logbuf_cgo
+
+// Package logbuf / sqlite_purego.go selects modernc.org/sqlite as the SQLite
+// driver for sqliteBuf. This is the default: it has no cgo dependency, so it
+// works for cross-compiled, static, and WASM builds that can't use
+// mattn/go-sqlite3. Build with the logbuf_cgo tag to opt back into that
+// driver instead.
+package logbuf
+
+import (
+	_ "modernc.org/sqlite"
+)
+
+// sqlDriverName is the database/sql driver name sqliteBuf opens. It is set
+// per build tag so NewSQliteBuffer picks whichever SQLite driver was
+// actually compiled in.
+const sqlDriverName = "sqlite"